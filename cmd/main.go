@@ -4,19 +4,28 @@ import (
 	"bytes"
 	"embed"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"html/template"
-	"io/ioutil"
 	"log"
 	"net/http"
 	"os"
 	"path/filepath"
+	"sort"
+	"strconv"
 	"strings"
 	"time"
 
 	"github.com/adrg/frontmatter"
 	"github.com/gofrs/uuid"
 	"github.com/nats-io/nats.go"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"github.com/s4yli/alerter/internal/dedup"
+	"github.com/s4yli/alerter/internal/digest"
+	"github.com/s4yli/alerter/internal/filter"
+	"github.com/s4yli/alerter/internal/health"
+	"github.com/s4yli/alerter/internal/metrics"
+	"github.com/s4yli/alerter/internal/notify"
 )
 
 // =====================================================================
@@ -27,8 +36,32 @@ var (
 	natsURL    = getEnv("NATS_URL", "nats://localhost:4222")
 	configURL  = getEnv("CONFIG_URL", "http://localhost:8080/alerts")
 	mailAPIURL = getEnv("MAIL_API_URL", "https://mail-api.edu.forestier.re/mail")
+	digestPath = getEnv("DIGEST_STORE_PATH", "digest-buffer.json")
+
+	smtpHost = getEnv("SMTP_HOST", "")
+	smtpPort = getEnv("SMTP_PORT", "587")
+	smtpUser = getEnv("SMTP_USER", "")
+	smtpPass = getEnv("SMTP_PASS", "")
+	smtpFrom = getEnv("SMTP_FROM", smtpUser)
+
+	jsStream         = getEnv("JETSTREAM_STREAM", "TIMETABLE")
+	jsSubject        = getEnv("JETSTREAM_SUBJECT", "TIMETABLE.ALERTER")
+	jsDurable        = getEnv("JETSTREAM_DURABLE", "alerter")
+	jsDeliverPolicy  = getEnv("JETSTREAM_DELIVER_POLICY", "all") // all | new | by_start_time
+	jsDeliverStart   = getEnv("JETSTREAM_DELIVER_START_TIME", "")
+	jsManage         = getEnv("JETSTREAM_MANAGE", "false") == "true"
+	jsMaxDeliver     = getEnvInt("JETSTREAM_MAX_DELIVER", 5)
+	jsAckWaitSeconds = getEnvInt("JETSTREAM_ACK_WAIT_SECONDS", 30)
+
+	dedupPath          = getEnv("DEDUP_STORE_PATH", "dedup-cache.json")
+	dedupRetentionDays = getEnvInt("DEDUP_RETENTION_DAYS", 7)
+	rateLimitPerHour   = getEnvInt("RATE_LIMIT_PER_HOUR", 20)
+
+	metricsAddr = getEnv("METRICS_ADDR", ":9090")
 )
 
+var healthStatus = health.NewStatus()
+
 func getEnv(key, defaultValue string) string {
 	if value, exists := os.LookupEnv(key); exists {
 		return value
@@ -36,6 +69,19 @@ func getEnv(key, defaultValue string) string {
 	return defaultValue
 }
 
+func getEnvInt(key string, defaultValue int) int {
+	value, exists := os.LookupEnv(key)
+	if !exists {
+		return defaultValue
+	}
+	n, err := strconv.Atoi(value)
+	if err != nil {
+		log.Printf("Valeur invalide pour %s (%q), utilisation du défaut %d", key, value, defaultValue)
+		return defaultValue
+	}
+	return n
+}
+
 // =====================================================================
 // Structures
 // =====================================================================
@@ -45,6 +91,45 @@ type Alert struct {
 	Email      string     `json:"email"`
 	All        bool       `json:"all"`
 	ResourceId *uuid.UUID `json:"resource_id"`
+
+	// Frequency vaut "immediate" (défaut), "daily" ou "weekly". En mode
+	// digest, les événements matchés sont bufferisés au lieu d'être
+	// mailés immédiatement.
+	Frequency string `json:"frequency"`
+	// DigestHour est l'heure (0-23, heure locale du serveur) à laquelle le
+	// digest "daily"/"weekly" de cette alerte est envoyé.
+	DigestHour int `json:"digest_hour"`
+
+	// Channel sélectionne le notifier utilisé pour cette alerte : "mail"
+	// (défaut, API mail historique), "smtp", "webhook", "mattermost" ou
+	// "discord". Target est l'URL de webhook des canaux "webhook",
+	// "mattermost" et "discord" ; les canaux "mail" et "smtp" l'ignorent et
+	// envoient toujours à Email.
+	Channel string `json:"channel"`
+	Target  string `json:"target"`
+
+	// Filter est une expression optionnelle évaluée contre chaque événement
+	// (ex: `event.Location contains "Amphi" && !isNew`). Elle est combinée en
+	// OR avec All/ResourceId : si elle matche, l'alerte s'applique même si
+	// All est faux et que ResourceId ne correspond pas.
+	Filter string `json:"filter"`
+}
+
+// isDigest indique si l'alerte doit bufferiser les événements plutôt que de
+// les mailer immédiatement.
+func (a Alert) isDigest() bool {
+	return a.Frequency == "daily" || a.Frequency == "weekly"
+}
+
+// key retourne l'identifiant stable de l'alerte utilisé comme clé de bucket
+// de digest ou de cache de déduplication, et false si l'alerte n'a pas d'Id
+// (config malformée) : les appelants doivent alors l'ignorer plutôt que de
+// déréférencer un *uuid.UUID nil.
+func (a Alert) key() (string, bool) {
+	if a.Id == nil {
+		return "", false
+	}
+	return a.Id.String(), true
 }
 
 type Event struct {
@@ -78,6 +163,27 @@ func formatICalDate(input string) string {
 	return t.Format("02/01/2006 15:04")
 }
 
+// groupEventsByResource répartit des événements par ResourceID, pour que le
+// template digest.txt puisse les afficher regroupés par ressource.
+func groupEventsByResource(events []Event) map[string][]Event {
+	groups := make(map[string][]Event)
+	for _, ev := range events {
+		groups[ev.ResourceID] = append(groups[ev.ResourceID], ev)
+	}
+	return groups
+}
+
+// sortEventsByStart trie une slice d'événements par DTSTART croissant, sans
+// modifier la slice d'origine.
+func sortEventsByStart(events []Event) []Event {
+	sorted := make([]Event, len(events))
+	copy(sorted, events)
+	sort.Slice(sorted, func(i, j int) bool {
+		return sorted[i].Dtstart < sorted[j].Dtstart
+	})
+	return sorted
+}
+
 func GetStringFromEmbeddedTemplate(templatePath string, body interface{}) (string, MailMatter, error) {
 	var matter MailMatter
 
@@ -87,6 +193,8 @@ func GetStringFromEmbeddedTemplate(templatePath string, body interface{}) (strin
 			"cleanDescription": func(desc string) string {
 				return strings.ReplaceAll(desc, `\n`, "\n")
 			},
+			"groupByResource": groupEventsByResource,
+			"sortByStart":     sortEventsByStart,
 		}).
 		ParseFS(embeddedTemplates, templatePath)
 	if err != nil {
@@ -107,45 +215,33 @@ func GetStringFromEmbeddedTemplate(templatePath string, body interface{}) (strin
 }
 
 // =====================================================================
-// Envoi d'email via l'API
+// Notifiers
 // =====================================================================
 
-func sendEmail(to, subject, content string) error {
-	payload := map[string]interface{}{
-		"recipient": to,
-		"subject":   subject,
-		"content":   content,
-	}
-
-	jsonPayload, err := json.Marshal(payload)
-	if err != nil {
-		return fmt.Errorf("erreur de sérialisation JSON: %v", err)
-	}
-
-	req, err := http.NewRequest("POST", mailAPIURL, bytes.NewBuffer(jsonPayload))
-	if err != nil {
-		return fmt.Errorf("erreur de création de la requête HTTP: %v", err)
-	}
-	req.Header.Set("Content-Type", "application/json")
-
-	token := os.Getenv("MAIL_TOKEN")
-	if token == "" {
-		return fmt.Errorf("MAIL_TOKEN non défini")
-	}
-	req.Header.Set("Authorization", token)
-
-	client := &http.Client{Timeout: 10 * time.Second}
-	resp, err := client.Do(req)
-	if err != nil {
-		return fmt.Errorf("erreur lors de l'exécution de la requête: %v", err)
-	}
-	defer resp.Body.Close()
+var (
+	mailNotifier       = notify.NewMailNotifier(mailAPIURL)
+	smtpNotifier       = notify.NewSMTPNotifier(smtpHost, smtpPort, smtpUser, smtpPass, smtpFrom)
+	webhookNotifier    = notify.NewWebhookNotifier()
+	mattermostNotifier = notify.NewMattermostNotifier()
+	discordNotifier    = notify.NewDiscordNotifier()
+)
 
-	if resp.StatusCode != http.StatusNoContent {
-		body, _ := ioutil.ReadAll(resp.Body)
-		return fmt.Errorf("échec de l'envoi du mail, status: %d, body: %s", resp.StatusCode, string(body))
+// resolveNotifier choisit le Notifier et la cible à utiliser pour une alerte,
+// selon son Channel ("mail" par défaut pour rester compatible avec les
+// alertes existantes qui ne renseignent pas ce champ).
+func resolveNotifier(alert Alert) (notify.Notifier, string) {
+	switch alert.Channel {
+	case "smtp":
+		return smtpNotifier, alert.Email
+	case "webhook":
+		return webhookNotifier, alert.Target
+	case "mattermost":
+		return mattermostNotifier, alert.Target
+	case "discord":
+		return discordNotifier, alert.Target
+	default:
+		return mailNotifier, alert.Email
 	}
-	return nil
 }
 
 // =====================================================================
@@ -156,18 +252,24 @@ func fetchAlerts() ([]Alert, error) {
 	client := &http.Client{Timeout: 5 * time.Second}
 	resp, err := client.Get(configURL)
 	if err != nil {
+		healthStatus.SetFetchResult(false)
 		return nil, fmt.Errorf("erreur de récupération des alertes: %v", err)
 	}
 	defer resp.Body.Close()
 
 	if resp.StatusCode != http.StatusOK {
+		healthStatus.SetFetchResult(false)
 		return nil, fmt.Errorf("status code %d de l'API Config", resp.StatusCode)
 	}
 
 	var alerts []Alert
 	if err := json.NewDecoder(resp.Body).Decode(&alerts); err != nil {
+		healthStatus.SetFetchResult(false)
 		return nil, fmt.Errorf("erreur de décodage des alertes: %v", err)
 	}
+
+	healthStatus.SetFetchResult(true)
+	metrics.LastAlertCount.Set(float64(len(alerts)))
 	return alerts, nil
 }
 
@@ -176,9 +278,17 @@ func fetchAlerts() ([]Alert, error) {
 // =====================================================================
 
 func processMessage(m *nats.Msg) {
+	start := time.Now()
+	defer func() {
+		metrics.ProcessingDuration.Observe(time.Since(start).Seconds())
+	}()
+
+	metrics.EventsReceived.Inc()
+
 	var event Event
 	if err := json.Unmarshal(m.Data, &event); err != nil {
 		log.Printf("Erreur de décodage de l'événement: %v", err)
+		ackMsg(m) // message malformé : pas la peine de le redélivrer
 		return
 	}
 
@@ -188,47 +298,297 @@ func processMessage(m *nats.Msg) {
 	alerts, err := fetchAlerts()
 	if err != nil {
 		log.Printf("Erreur lors de la récupération des alertes: %v", err)
+		nakMsg(m) // échec transitoire : on redemande une livraison
 		return
 	}
 
+	transientFailure := false
+
 	for _, alert := range alerts {
-		apply := alert.All ||
-			(alert.ResourceId != nil &&
-				strings.EqualFold(event.ResourceID, alert.ResourceId.String()))
-
-		if apply {
-			const templatePath = "templates/alert.txt"
-			body := map[string]interface{}{
-				"Event": event,
-				"IsNew": isNew,
-			}
+		matchesResource := alert.ResourceId != nil &&
+			strings.EqualFold(event.ResourceID, alert.ResourceId.String())
+		apply := alert.All || matchesResource || filterMatches(alert, event, isNew)
 
-			htmlContent, matter, err := GetStringFromEmbeddedTemplate(templatePath, body)
-			if err != nil {
-				log.Printf("Erreur template pour l'alerte %s: %v", alert.Id, err)
-				continue
-			}
+		if !apply {
+			continue
+		}
+		metrics.EventsMatched.Inc()
 
-			if err = sendEmail(alert.Email, matter.Subject, htmlContent); err != nil {
-				log.Printf("Échec envoi à %s (alerte %s): %v",
-					maskEmail(alert.Email),
-					alert.Id,
-					err)
-				continue
+		if alert.isDigest() {
+			if err := bufferEvent(alert, event); err != nil {
+				log.Printf("Erreur de mise en tampon pour l'alerte %s: %v", alert.Id, err)
+				transientFailure = true
 			}
-			log.Printf("Email envoyé à %s", maskEmail(alert.Email))
+			continue
+		}
+
+		const templatePath = "templates/alert.txt"
+		body := map[string]interface{}{
+			"Event": event,
+			"IsNew": isNew,
+		}
+
+		htmlContent, matter, err := GetStringFromEmbeddedTemplate(templatePath, body)
+		if err != nil {
+			log.Printf("Erreur template pour l'alerte %s: %v", alert.Id, err)
+			metrics.TemplateErrors.Inc()
+			continue
+		}
+
+		if err := dispatch(alert, event, matter.Subject, htmlContent); err != nil {
+			log.Printf("Échec envoi pour l'alerte %s: %v", alert.Id, err)
+			transientFailure = true
+			continue
+		}
+	}
+
+	if transientFailure {
+		nakMsg(m)
+		return
+	}
+	ackMsg(m)
+}
+
+var filterEvaluator = filter.NewEvaluator()
+
+// filterMatches compile (avec mise en cache) et évalue le filtre de l'alerte
+// contre l'événement courant. Une alerte sans filtre, ou un filtre invalide,
+// ne matche jamais par ce biais.
+func filterMatches(alert Alert, event Event, isNew bool) bool {
+	if alert.Filter == "" {
+		return false
+	}
+
+	program, err := filterEvaluator.Compile(alert.Filter)
+	if err != nil {
+		log.Printf("Filtre invalide pour l'alerte %s: %v", alert.Id, err)
+		return false
+	}
+
+	matched, err := program.Eval(eventToMap(event), isNew)
+	if err != nil {
+		log.Printf("Erreur d'évaluation du filtre pour l'alerte %s: %v", alert.Id, err)
+		return false
+	}
+	return matched
+}
+
+// eventToMap expose les champs de l'événement sous les noms utilisés dans
+// les expressions de filtre (event.Location, event.Dtstart, ...).
+func eventToMap(event Event) map[string]interface{} {
+	return map[string]interface{}{
+		"Id":           event.Id,
+		"Dtstamp":      event.Dtstamp,
+		"Dtstart":      event.Dtstart,
+		"Dtend":        event.Dtend,
+		"Description":  event.Description,
+		"Location":     event.Location,
+		"Created":      event.Created,
+		"LastModified": event.LastModified,
+		"ResourceID":   event.ResourceID,
+	}
+}
+
+// ackMsg et nakMsg acquittent (ou redemandent la livraison de) le message
+// JetStream sous-jacent. Sans JetStream (message core NATS), m.Ack()/m.Nak()
+// renvoient nats.ErrMsgNoReply faute de reply-to : cette erreur est ignorée,
+// les autres sont seulement loggées.
+func ackMsg(m *nats.Msg) {
+	if err := m.Ack(); err != nil && err != nats.ErrMsgNoReply {
+		log.Printf("Erreur lors de l'ack du message: %v", err)
+	}
+}
+
+func nakMsg(m *nats.Msg) {
+	if err := m.Nak(); err != nil && err != nats.ErrMsgNoReply {
+		log.Printf("Erreur lors du nak du message: %v", err)
+	}
+}
+
+// =====================================================================
+// Digest / newsletter
+// =====================================================================
+
+var digestStore *digest.Store
+
+// bufferEvent ajoute l'événement au bucket de l'alerte plutôt que de
+// l'envoyer immédiatement ; il sera mailé groupé au prochain flush du digest.
+func bufferEvent(alert Alert, event Event) error {
+	if digestStore == nil {
+		return fmt.Errorf("digest non initialisé")
+	}
+
+	alertID, ok := alert.key()
+	if !ok {
+		return fmt.Errorf("alerte sans id, impossible de la bufferiser")
+	}
+
+	data, err := json.Marshal(event)
+	if err != nil {
+		return fmt.Errorf("sérialisation de l'événement: %v", err)
+	}
+
+	ev := digest.BufferedEvent{
+		ResourceID: event.ResourceID,
+		Data:       data,
+		ReceivedAt: time.Now(),
+	}
+	return digestStore.Append(alertID, ev)
+}
+
+// listDigestAlerts retourne les AlertRef des alertes en mode "daily"/"weekly",
+// pour le compte du scheduler de digest.
+func listDigestAlerts() ([]digest.AlertRef, error) {
+	alerts, err := fetchAlerts()
+	if err != nil {
+		return nil, err
+	}
+
+	refs := make([]digest.AlertRef, 0, len(alerts))
+	for _, alert := range alerts {
+		if !alert.isDigest() {
+			continue
+		}
+		alertID, ok := alert.key()
+		if !ok {
+			log.Printf("Alerte digest sans id ignorée")
+			continue
+		}
+		refs = append(refs, digest.AlertRef{
+			ID:         alertID,
+			Frequency:  alert.Frequency,
+			DigestHour: alert.DigestHour,
+		})
+	}
+	return refs, nil
+}
+
+// flushDigest rend templates/digest.txt à partir des événements accumulés et
+// envoie le mail récapitulatif correspondant à l'alerte.
+func flushDigest(alertID string, buffered []digest.BufferedEvent) error {
+	alerts, err := fetchAlerts()
+	if err != nil {
+		return fmt.Errorf("récupération des alertes: %v", err)
+	}
+
+	var alert *Alert
+	for i := range alerts {
+		if alerts[i].Id != nil && alerts[i].Id.String() == alertID {
+			alert = &alerts[i]
+			break
+		}
+	}
+	if alert == nil {
+		return fmt.Errorf("alerte %s introuvable", alertID)
+	}
+
+	events := make([]Event, 0, len(buffered))
+	for _, ev := range buffered {
+		var event Event
+		if err := json.Unmarshal(ev.Data, &event); err != nil {
+			log.Printf("Erreur de décodage d'un événement bufferisé pour l'alerte %s: %v", alertID, err)
+			continue
 		}
+		events = append(events, event)
 	}
+
+	const templatePath = "templates/digest.txt"
+	body := map[string]interface{}{
+		"Events": events,
+	}
+
+	content, matter, err := GetStringFromEmbeddedTemplate(templatePath, body)
+	if err != nil {
+		return fmt.Errorf("rendu du template digest: %v", err)
+	}
+
+	notifier, target := resolveNotifier(*alert)
+	if err := notifier.Send(target, matter.Subject, content); err != nil {
+		return fmt.Errorf("envoi du digest: %v", err)
+	}
+	log.Printf("Digest envoyé à %s (%d événement(s))", maskTarget(target), len(events))
+	return nil
 }
 
-func maskEmail(email string) string {
-	parts := strings.Split(email, "@")
-	if len(parts) != 2 {
-		return "***@***"
+// maskTarget masque une adresse email ou une URL de webhook avant de
+// l'écrire dans les logs.
+func maskTarget(target string) string {
+	if parts := strings.Split(target, "@"); len(parts) == 2 && parts[0] != "" && parts[1] != "" {
+		return fmt.Sprintf("%s***@***%s",
+			string(parts[0][0]),
+			string(parts[1][len(parts[1])-1]))
+	}
+	if len(target) <= 12 {
+		return "***"
+	}
+	return target[:8] + "***" + target[len(target)-4:]
+}
+
+// =====================================================================
+// Déduplication et rate-limit
+// =====================================================================
+
+var (
+	dedupCache  *dedup.Cache
+	rateLimiter *dedup.RateLimiter
+)
+
+// dispatch consulte le cache de déduplication et le rate limiter avant
+// d'envoyer une notification immédiate (non-digest) : un événement déjà
+// notifié pour cette alerte est ignoré, et un destinataire qui dépasse son
+// quota horaire voit son événement abandonné (et compté) plutôt que mailé.
+//
+// Le bucket de digest n'est volontairement pas utilisé ici : il n'est flushé
+// que pour les alertes "daily"/"weekly" (voir listDigestAlerts), et dispatch
+// n'est appelé que pour les alertes immédiates — y écrire reviendrait à
+// perdre l'événement dans un bucket que rien ne lit jamais.
+func dispatch(alert Alert, event Event, subject, content string) error {
+	alertID, hasID := alert.key()
+	if !hasID {
+		log.Printf("Alerte sans id: déduplication désactivée pour cet envoi")
+	}
+
+	if hasID {
+		seen, err := dedupCache.Seen(alertID, event.Id, event.LastModified)
+		if err != nil {
+			log.Printf("Erreur de consultation du cache de déduplication pour l'alerte %s: %v", alertID, err)
+		} else if seen {
+			log.Printf("Événement %s déjà notifié pour l'alerte %s, ignoré", event.Id, alertID)
+			return nil
+		}
+	}
+
+	notifier, target := resolveNotifier(alert)
+
+	if !rateLimiter.Allow(target) {
+		log.Printf("Limite de débit atteinte pour %s, événement %s abandonné (alerte %s)",
+			maskTarget(target), event.Id, alert.Id)
+		metrics.RateLimitDrops.Inc()
+		return nil
+	}
+
+	sendStart := time.Now()
+	err := notifier.Send(target, subject, content)
+	metrics.NotifyDuration.Observe(time.Since(sendStart).Seconds())
+
+	if err != nil {
+		statusCode := "0"
+		var statusErr *notify.StatusError
+		if errors.As(err, &statusErr) {
+			statusCode = strconv.Itoa(statusErr.Code)
+		}
+		metrics.NotificationFailures.WithLabelValues(statusCode).Inc()
+		return fmt.Errorf("échec envoi à %s: %v", maskTarget(target), err)
+	}
+	metrics.NotificationsSent.Inc()
+	log.Printf("Notification envoyée à %s", maskTarget(target))
+
+	if hasID {
+		if err := dedupCache.Record(alertID, event.Id, event.LastModified); err != nil {
+			log.Printf("Erreur d'enregistrement de déduplication pour l'alerte %s: %v", alertID, err)
+		}
 	}
-	return fmt.Sprintf("%s***@***%s",
-		string(parts[0][0]),
-		string(parts[1][len(parts[1])-1]))
+	return nil
 }
 
 // =====================================================================
@@ -236,27 +596,118 @@ func maskEmail(email string) string {
 // =====================================================================
 
 func main() {
+	store, err := digest.NewStore(digestPath)
+	if err != nil {
+		log.Fatal("Erreur d'initialisation du tampon de digest:", err)
+	}
+	digestStore = store
+
+	cache, err := dedup.NewCache(dedupPath, time.Duration(dedupRetentionDays)*24*time.Hour)
+	if err != nil {
+		log.Fatal("Erreur d'initialisation du cache de déduplication:", err)
+	}
+	dedupCache = cache
+	rateLimiter = dedup.NewRateLimiter(rateLimitPerHour, time.Hour)
+
+	scheduler := digest.NewScheduler(store, time.Minute, listDigestAlerts, flushDigest)
+	go scheduler.Run(make(chan struct{}))
+
+	go serveMetrics()
+
 	nc, err := nats.Connect(natsURL,
 		nats.MaxReconnects(5),
 		nats.ReconnectWait(2*time.Second),
 		nats.DisconnectErrHandler(func(nc *nats.Conn, err error) {
 			log.Printf("Déconnecté de NATS: %v", err)
+			healthStatus.SetNATSConnected(false)
+			metrics.NatsConnected.Set(0)
 		}),
 		nats.ReconnectHandler(func(nc *nats.Conn) {
 			log.Printf("Reconnecté à NATS @ %s", nc.ConnectedUrl())
+			healthStatus.SetNATSConnected(true)
+			metrics.NatsConnected.Set(1)
+			metrics.NatsReconnects.Inc()
 		}))
 	if err != nil {
 		log.Fatal("Erreur de connexion à NATS:", err)
 	}
 	defer nc.Close()
 
-	_, err = nc.Subscribe("TIMETABLE.ALERTER", func(m *nats.Msg) {
+	healthStatus.SetNATSConnected(true)
+	metrics.NatsConnected.Set(1)
+
+	js, err := nc.JetStream()
+	if err != nil {
+		log.Fatal("Erreur d'initialisation de JetStream:", err)
+	}
+
+	if jsManage {
+		if err := ensureStream(js); err != nil {
+			log.Fatal("Erreur de création du stream JetStream:", err)
+		}
+	}
+
+	subOpts := []nats.SubOpt{
+		nats.Durable(jsDurable),
+		nats.ManualAck(),
+		nats.AckWait(time.Duration(jsAckWaitSeconds) * time.Second),
+		nats.MaxDeliver(jsMaxDeliver),
+		deliverPolicyOpt(),
+	}
+
+	_, err = js.Subscribe(jsSubject, func(m *nats.Msg) {
 		processMessage(m)
-	})
+	}, subOpts...)
 	if err != nil {
-		log.Fatal("Erreur lors de la souscription:", err)
+		log.Fatal("Erreur lors de la souscription JetStream:", err)
 	}
 
-	log.Printf("Service démarré - En écoute sur %s", natsURL)
+	log.Printf("Service démarré - En écoute sur %s (stream=%s, durable=%s)", natsURL, jsStream, jsDurable)
 	select {} // Bloque indéfiniment
 }
+
+// ensureStream crée le stream JetStream s'il n'existe pas déjà. N'est appelé
+// que si JETSTREAM_MANAGE=true : en production, la gestion du stream est
+// généralement déléguée à un outil de provisioning séparé.
+func ensureStream(js nats.JetStreamContext) error {
+	if _, err := js.StreamInfo(jsStream); err == nil {
+		return nil
+	}
+
+	_, err := js.AddStream(&nats.StreamConfig{
+		Name:     jsStream,
+		Subjects: []string{jsSubject},
+	})
+	return err
+}
+
+// deliverPolicyOpt traduit JETSTREAM_DELIVER_POLICY ("all", "new" ou
+// "by_start_time") en option de souscription JetStream.
+func deliverPolicyOpt() nats.SubOpt {
+	switch jsDeliverPolicy {
+	case "new":
+		return nats.DeliverNew()
+	case "by_start_time":
+		if t, err := time.Parse(time.RFC3339, jsDeliverStart); err == nil {
+			return nats.StartTime(t)
+		}
+		log.Printf("JETSTREAM_DELIVER_START_TIME invalide ou absent, repli sur DeliverAll")
+		return nats.DeliverAll()
+	default:
+		return nats.DeliverAll()
+	}
+}
+
+// serveMetrics démarre le serveur HTTP exposant /metrics (Prometheus),
+// /healthz (liveness) et /readyz (readiness, basé sur healthStatus).
+func serveMetrics() {
+	mux := http.NewServeMux()
+	mux.Handle("/metrics", promhttp.Handler())
+	mux.HandleFunc("/healthz", healthStatus.HealthzHandler)
+	mux.HandleFunc("/readyz", healthStatus.ReadyzHandler)
+
+	log.Printf("Serveur de métriques démarré sur %s", metricsAddr)
+	if err := http.ListenAndServe(metricsAddr, mux); err != nil {
+		log.Fatal("Erreur du serveur de métriques:", err)
+	}
+}
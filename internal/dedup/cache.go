@@ -0,0 +1,98 @@
+// Package dedup évite de renvoyer une notification déjà envoyée pour le même
+// événement (même UID + même LAST-MODIFIED) et plafonne le débit de
+// notifications par destinataire, pour absorber les replays JetStream ou les
+// publications redondantes côté config.
+package dedup
+
+import (
+	"encoding/json"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Cache persiste, pour chaque (alerte, événement, LAST-MODIFIED) déjà
+// notifié, la date d'envoi, dans un unique fichier JSON. Les entrées plus
+// vieilles que la fenêtre de rétention sont purgées au chargement.
+type Cache struct {
+	path      string
+	retention time.Duration
+	mu        sync.Mutex
+}
+
+// NewCache crée (si besoin) le fichier de cache à l'emplacement donné.
+func NewCache(path string, retention time.Duration) (*Cache, error) {
+	c := &Cache{path: path, retention: retention}
+	if _, err := os.Stat(path); os.IsNotExist(err) {
+		if err := c.save(map[string]time.Time{}); err != nil {
+			return nil, err
+		}
+	}
+	return c, nil
+}
+
+func dedupKey(alertID, eventID, lastModified string) string {
+	return strings.Join([]string{alertID, eventID, lastModified}, "|")
+}
+
+func (c *Cache) load() (map[string]time.Time, error) {
+	entries := map[string]time.Time{}
+
+	data, err := os.ReadFile(c.path)
+	if os.IsNotExist(err) {
+		return entries, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if len(data) == 0 {
+		return entries, nil
+	}
+	if err := json.Unmarshal(data, &entries); err != nil {
+		return nil, err
+	}
+
+	cutoff := time.Now().Add(-c.retention)
+	for key, sentAt := range entries {
+		if sentAt.Before(cutoff) {
+			delete(entries, key)
+		}
+	}
+	return entries, nil
+}
+
+func (c *Cache) save(entries map[string]time.Time) error {
+	data, err := json.MarshalIndent(entries, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(c.path, data, 0644)
+}
+
+// Seen indique si (alertID, eventID, lastModified) a déjà été notifié dans la
+// fenêtre de rétention, sans modifier le cache.
+func (c *Cache) Seen(alertID, eventID, lastModified string) (bool, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	entries, err := c.load()
+	if err != nil {
+		return false, err
+	}
+	_, ok := entries[dedupKey(alertID, eventID, lastModified)]
+	return ok, nil
+}
+
+// Record marque (alertID, eventID, lastModified) comme notifié à l'instant présent.
+func (c *Cache) Record(alertID, eventID, lastModified string) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	entries, err := c.load()
+	if err != nil {
+		return err
+	}
+	entries[dedupKey(alertID, eventID, lastModified)] = time.Now()
+	return c.save(entries)
+}
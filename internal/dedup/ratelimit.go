@@ -0,0 +1,51 @@
+package dedup
+
+import (
+	"sync"
+	"time"
+)
+
+// RateLimiter est un limiteur à fenêtre fixe (N envois max par fenêtre et par
+// destinataire, la fenêtre démarrant au premier envoi), suffisant pour plafonner le débit de mails
+// sans la complexité d'un vrai token bucket.
+type RateLimiter struct {
+	mu      sync.Mutex
+	limit   int
+	window  time.Duration
+	buckets map[string]*bucket
+}
+
+type bucket struct {
+	count       int
+	windowStart time.Time
+}
+
+// NewRateLimiter crée un RateLimiter autorisant au plus `limit` envois par
+// `window` et par destinataire.
+func NewRateLimiter(limit int, window time.Duration) *RateLimiter {
+	return &RateLimiter{limit: limit, window: window, buckets: map[string]*bucket{}}
+}
+
+// Allow indique si un envoi vers `recipient` est autorisé maintenant, et
+// consomme un crédit de la fenêtre courante si oui.
+func (r *RateLimiter) Allow(recipient string) bool {
+	if r.limit <= 0 {
+		return true // pas de limite configurée
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	b, ok := r.buckets[recipient]
+	now := time.Now()
+	if !ok || now.Sub(b.windowStart) >= r.window {
+		b = &bucket{count: 0, windowStart: now}
+		r.buckets[recipient] = b
+	}
+
+	if b.count >= r.limit {
+		return false
+	}
+	b.count++
+	return true
+}
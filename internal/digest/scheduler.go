@@ -0,0 +1,116 @@
+package digest
+
+import (
+	"log"
+	"sync"
+	"time"
+)
+
+// AlertRef est la vue réduite d'une alerte dont le scheduler a besoin pour
+// décider quand la vider, sans dépendre du type Alert du package main.
+type AlertRef struct {
+	ID         string
+	Frequency  string
+	DigestHour int
+}
+
+// FlushFunc reçoit les événements accumulés d'une alerte et se charge de
+// rendre le template de digest et d'envoyer le mail correspondant.
+type FlushFunc func(alertID string, events []BufferedEvent) error
+
+// Scheduler déclenche, via un ticker, le vidage des buckets "daily"/"weekly"
+// dès que l'heure courante correspond au DigestHour configuré sur l'alerte.
+type Scheduler struct {
+	store      *Store
+	interval   time.Duration
+	listAlerts func() ([]AlertRef, error)
+	flush      FlushFunc
+
+	mu        sync.Mutex
+	lastFlush map[string]string
+}
+
+// NewScheduler construit un Scheduler. listAlerts est rappelée à chaque tick
+// pour obtenir la liste à jour des alertes en mode digest.
+func NewScheduler(store *Store, interval time.Duration, listAlerts func() ([]AlertRef, error), flush FlushFunc) *Scheduler {
+	return &Scheduler{store: store, interval: interval, listAlerts: listAlerts, flush: flush, lastFlush: map[string]string{}}
+}
+
+// Run bloque et déclenche un tick à chaque intervalle jusqu'à ce que stop soit fermé.
+func (s *Scheduler) Run(stop <-chan struct{}) {
+	ticker := time.NewTicker(s.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-stop:
+			return
+		case now := <-ticker.C:
+			s.tick(now)
+		}
+	}
+}
+
+func (s *Scheduler) tick(now time.Time) {
+	alerts, err := s.listAlerts()
+	if err != nil {
+		log.Printf("Digest: impossible de lister les alertes: %v", err)
+		return
+	}
+
+	for _, alert := range alerts {
+		if alert.Frequency != "daily" && alert.Frequency != "weekly" {
+			continue
+		}
+		if now.Hour() != alert.DigestHour {
+			continue
+		}
+		if alert.Frequency == "weekly" && now.Weekday() != time.Monday {
+			continue
+		}
+		if !s.shouldFlush(alert.ID, now) {
+			continue
+		}
+
+		events, err := s.store.Flush(alert.ID)
+		if err != nil {
+			log.Printf("Digest: échec du flush pour l'alerte %s: %v", alert.ID, err)
+			s.clearLastFlush(alert.ID)
+			continue
+		}
+		if len(events) == 0 {
+			continue
+		}
+		if err := s.flush(alert.ID, events); err != nil {
+			log.Printf("Digest: échec d'envoi pour l'alerte %s: %v", alert.ID, err)
+			if restoreErr := s.store.Restore(alert.ID, events); restoreErr != nil {
+				log.Printf("Digest: échec de la remise en tampon pour l'alerte %s: %v", alert.ID, restoreErr)
+			}
+			s.clearLastFlush(alert.ID)
+		}
+	}
+}
+
+// shouldFlush indique si l'alerte n'a pas déjà été vidée pour le créneau
+// courant (le jour calendaire local, DigestHour étant exprimé en heure
+// locale comme le gate de tick) et, si c'est le cas, marque immédiatement
+// le créneau comme traité pour éviter qu'un tick suivant dans la même heure
+// ne déclenche un nouveau digest. clearLastFlush permet de revenir en
+// arrière si le flush échoue.
+func (s *Scheduler) shouldFlush(alertID string, now time.Time) bool {
+	slot := now.Format("2006-01-02")
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if last, ok := s.lastFlush[alertID]; ok && last == slot {
+		return false
+	}
+	s.lastFlush[alertID] = slot
+	return true
+}
+
+func (s *Scheduler) clearLastFlush(alertID string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.lastFlush, alertID)
+}
@@ -0,0 +1,117 @@
+// Package digest gère la mise en tampon des événements pour les alertes
+// configurées en fréquence "daily" ou "weekly", afin de regrouper plusieurs
+// événements dans un seul mail au lieu d'en envoyer un par changement.
+package digest
+
+import (
+	"encoding/json"
+	"os"
+	"sync"
+	"time"
+)
+
+// BufferedEvent est un événement tel que stocké dans un bucket, en attendant
+// le prochain flush du digest de l'alerte concernée.
+type BufferedEvent struct {
+	ResourceID string          `json:"resource_id"`
+	Data       json.RawMessage `json:"data"`
+	ReceivedAt time.Time       `json:"received_at"`
+}
+
+// Store persiste les buckets d'événements en attente sur disque, sous la
+// forme d'un unique fichier JSON indexé par identifiant d'alerte.
+type Store struct {
+	path string
+	mu   sync.Mutex
+}
+
+// NewStore crée (si besoin) le fichier de tampon à l'emplacement donné.
+func NewStore(path string) (*Store, error) {
+	s := &Store{path: path}
+	if _, err := os.Stat(path); os.IsNotExist(err) {
+		if err := s.save(map[string][]BufferedEvent{}); err != nil {
+			return nil, err
+		}
+	}
+	return s, nil
+}
+
+func (s *Store) load() (map[string][]BufferedEvent, error) {
+	buckets := map[string][]BufferedEvent{}
+
+	data, err := os.ReadFile(s.path)
+	if os.IsNotExist(err) {
+		return buckets, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if len(data) == 0 {
+		return buckets, nil
+	}
+	if err := json.Unmarshal(data, &buckets); err != nil {
+		return nil, err
+	}
+	return buckets, nil
+}
+
+func (s *Store) save(buckets map[string][]BufferedEvent) error {
+	data, err := json.MarshalIndent(buckets, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(s.path, data, 0644)
+}
+
+// Append ajoute un événement au bucket de l'alerte donnée.
+func (s *Store) Append(alertID string, ev BufferedEvent) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	buckets, err := s.load()
+	if err != nil {
+		return err
+	}
+	buckets[alertID] = append(buckets[alertID], ev)
+	return s.save(buckets)
+}
+
+// Flush retourne les événements accumulés pour une alerte et vide son bucket.
+func (s *Store) Flush(alertID string) ([]BufferedEvent, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	buckets, err := s.load()
+	if err != nil {
+		return nil, err
+	}
+	events := buckets[alertID]
+	if len(events) == 0 {
+		return nil, nil
+	}
+	delete(buckets, alertID)
+	return events, s.save(buckets)
+}
+
+// Restore replace les événements en tête du bucket de l'alerte donnée,
+// utilisé par le scheduler pour remettre en attente un lot dont l'envoi a
+// échoué après un Flush, afin qu'un prochain tick retente l'envoi.
+func (s *Store) Restore(alertID string, events []BufferedEvent) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	buckets, err := s.load()
+	if err != nil {
+		return err
+	}
+	buckets[alertID] = append(append([]BufferedEvent{}, events...), buckets[alertID]...)
+	return s.save(buckets)
+}
+
+// Buckets retourne un instantané de tous les buckets actuellement en attente,
+// utile pour que le scheduler sache quelles alertes ont du contenu à envoyer.
+func (s *Store) Buckets() (map[string][]BufferedEvent, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.load()
+}
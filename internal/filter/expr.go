@@ -0,0 +1,247 @@
+package filter
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// evalContext porte les variables accessibles depuis une expression de
+// filtre : l'événement courant (event.<Champ>) et l'indicateur isNew.
+type evalContext struct {
+	event map[string]interface{}
+	isNew bool
+}
+
+// expr est un nœud de l'arbre syntaxique d'une expression de filtre.
+type expr interface {
+	eval(ctx *evalContext) (interface{}, error)
+}
+
+// --- Littéraux et variables ---------------------------------------------
+
+type boolLit bool
+
+func (b boolLit) eval(*evalContext) (interface{}, error) { return bool(b), nil }
+
+type stringLit string
+
+func (s stringLit) eval(*evalContext) (interface{}, error) { return string(s), nil }
+
+type isNewVar struct{}
+
+func (isNewVar) eval(ctx *evalContext) (interface{}, error) { return ctx.isNew, nil }
+
+type nowCall struct{}
+
+func (nowCall) eval(*evalContext) (interface{}, error) { return time.Now(), nil }
+
+// fieldRef référence un champ de l'événement, ex. event.Location.
+type fieldRef struct {
+	name string
+}
+
+func (f fieldRef) eval(ctx *evalContext) (interface{}, error) {
+	v, ok := ctx.event[f.name]
+	if !ok {
+		return nil, fmt.Errorf("champ d'événement inconnu: %s", f.name)
+	}
+	return v, nil
+}
+
+// --- Arithmétique sur les durées -----------------------------------------
+
+// addDuration représente `value + duration` ou `value - duration`, utilisé
+// typiquement pour écrire `now() + 24h`.
+type addDuration struct {
+	left  expr
+	neg   bool
+	delta time.Duration
+}
+
+func (a addDuration) eval(ctx *evalContext) (interface{}, error) {
+	v, err := a.left.eval(ctx)
+	if err != nil {
+		return nil, err
+	}
+	t, ok := v.(time.Time)
+	if !ok {
+		return nil, fmt.Errorf("opérande de + ou - doit être une date (reçu %T)", v)
+	}
+	if a.neg {
+		return t.Add(-a.delta), nil
+	}
+	return t.Add(a.delta), nil
+}
+
+// --- Opérateurs logiques ---------------------------------------------------
+
+type notExpr struct{ operand expr }
+
+func (n notExpr) eval(ctx *evalContext) (interface{}, error) {
+	v, err := n.operand.eval(ctx)
+	if err != nil {
+		return nil, err
+	}
+	b, ok := v.(bool)
+	if !ok {
+		return nil, fmt.Errorf("opérande de ! doit être un booléen (reçu %T)", v)
+	}
+	return !b, nil
+}
+
+type andExpr struct{ left, right expr }
+
+func (a andExpr) eval(ctx *evalContext) (interface{}, error) {
+	l, err := a.left.eval(ctx)
+	if err != nil {
+		return nil, err
+	}
+	lb, ok := l.(bool)
+	if !ok {
+		return nil, fmt.Errorf("opérande de && doit être un booléen (reçu %T)", l)
+	}
+	if !lb {
+		return false, nil
+	}
+	r, err := a.right.eval(ctx)
+	if err != nil {
+		return nil, err
+	}
+	rb, ok := r.(bool)
+	if !ok {
+		return nil, fmt.Errorf("opérande de && doit être un booléen (reçu %T)", r)
+	}
+	return rb, nil
+}
+
+type orExpr struct{ left, right expr }
+
+func (o orExpr) eval(ctx *evalContext) (interface{}, error) {
+	l, err := o.left.eval(ctx)
+	if err != nil {
+		return nil, err
+	}
+	lb, ok := l.(bool)
+	if !ok {
+		return nil, fmt.Errorf("opérande de || doit être un booléen (reçu %T)", l)
+	}
+	if lb {
+		return true, nil
+	}
+	r, err := o.right.eval(ctx)
+	if err != nil {
+		return nil, err
+	}
+	rb, ok := r.(bool)
+	if !ok {
+		return nil, fmt.Errorf("opérande de || doit être un booléen (reçu %T)", r)
+	}
+	return rb, nil
+}
+
+// --- Comparaisons ----------------------------------------------------------
+
+type compareOp string
+
+const (
+	opEq       compareOp = "=="
+	opNeq      compareOp = "!="
+	opContains compareOp = "contains"
+	opBefore   compareOp = "before"
+	opAfter    compareOp = "after"
+	opMatches  compareOp = "matches"
+)
+
+type compareExpr struct {
+	left, right expr
+	op          compareOp
+}
+
+func (c compareExpr) eval(ctx *evalContext) (interface{}, error) {
+	l, err := c.left.eval(ctx)
+	if err != nil {
+		return nil, err
+	}
+	r, err := c.right.eval(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	switch c.op {
+	case opEq:
+		return l == r, nil
+	case opNeq:
+		return l != r, nil
+	case opContains:
+		ls, rs, err := asStrings(l, r)
+		if err != nil {
+			return nil, err
+		}
+		return strings.Contains(ls, rs), nil
+	case opMatches:
+		ls, rs, err := asStrings(l, r)
+		if err != nil {
+			return nil, err
+		}
+		re, err := regexp.Compile(rs)
+		if err != nil {
+			return nil, fmt.Errorf("expression régulière invalide %q: %v", rs, err)
+		}
+		return re.MatchString(ls), nil
+	case opBefore, opAfter:
+		lt, rt, err := asTimes(l, r)
+		if err != nil {
+			return nil, err
+		}
+		if c.op == opBefore {
+			return lt.Before(rt), nil
+		}
+		return lt.After(rt), nil
+	default:
+		return nil, fmt.Errorf("opérateur de comparaison inconnu: %s", c.op)
+	}
+}
+
+func asStrings(l, r interface{}) (string, string, error) {
+	ls, ok := l.(string)
+	if !ok {
+		return "", "", fmt.Errorf("opérande gauche doit être une chaîne (reçu %T)", l)
+	}
+	rs, ok := r.(string)
+	if !ok {
+		return "", "", fmt.Errorf("opérande droit doit être une chaîne (reçu %T)", r)
+	}
+	return ls, rs, nil
+}
+
+func asTimes(l, r interface{}) (time.Time, time.Time, error) {
+	lt, err := asTime(l)
+	if err != nil {
+		return time.Time{}, time.Time{}, fmt.Errorf("opérande gauche: %v", err)
+	}
+	rt, err := asTime(r)
+	if err != nil {
+		return time.Time{}, time.Time{}, fmt.Errorf("opérande droit: %v", err)
+	}
+	return lt, rt, nil
+}
+
+// asTime convertit en time.Time soit une valeur déjà temporelle (ex: now()),
+// soit une chaîne au format iCal (DTSTART/DTEND/...), tel que renvoyé par
+// l'API de config.
+func asTime(v interface{}) (time.Time, error) {
+	switch t := v.(type) {
+	case time.Time:
+		return t, nil
+	case string:
+		parsed, err := time.Parse("20060102T150405Z", t)
+		if err != nil {
+			return time.Time{}, fmt.Errorf("date invalide %q: %v", t, err)
+		}
+		return parsed, nil
+	default:
+		return time.Time{}, fmt.Errorf("doit être une date (reçu %T)", v)
+	}
+}
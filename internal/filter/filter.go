@@ -0,0 +1,81 @@
+// Package filter fournit un petit évaluateur d'expressions pour le champ
+// Alert.Filter, qui permet d'exprimer des règles de correspondance fines
+// sans modification de code, par ex. :
+//
+//	event.Location contains "Amphi" && !isNew
+//	event.Dtstart before now() + 24h
+//
+// Les expressions sont compilées une seule fois puis mises en cache par hash
+// du texte source, pour éviter de reparser à chaque événement.
+package filter
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"sync"
+)
+
+// Program est une expression de filtre compilée, prête à être évaluée.
+type Program struct {
+	expr expr
+}
+
+// Evaluator compile et met en cache les programmes de filtre, indexés par
+// hash de l'expression source.
+type Evaluator struct {
+	mu    sync.Mutex
+	cache map[string]*Program
+}
+
+// NewEvaluator crée un Evaluator avec un cache de programmes vide.
+func NewEvaluator() *Evaluator {
+	return &Evaluator{cache: map[string]*Program{}}
+}
+
+// Compile parse l'expression source et retourne le programme correspondant,
+// en réutilisant le cache si cette expression a déjà été compilée.
+func (e *Evaluator) Compile(source string) (*Program, error) {
+	key := hashSource(source)
+
+	e.mu.Lock()
+	if p, ok := e.cache[key]; ok {
+		e.mu.Unlock()
+		return p, nil
+	}
+	e.mu.Unlock()
+
+	ex, err := parse(source)
+	if err != nil {
+		return nil, fmt.Errorf("erreur de compilation du filtre %q: %v", source, err)
+	}
+	p := &Program{expr: ex}
+
+	e.mu.Lock()
+	e.cache[key] = p
+	e.mu.Unlock()
+	return p, nil
+}
+
+func hashSource(source string) string {
+	sum := sha256.Sum256([]byte(source))
+	return hex.EncodeToString(sum[:])
+}
+
+// Eval évalue le programme contre un événement représenté sous forme de map
+// (clés = noms de champs tels qu'écrits après `event.` dans l'expression, ex.
+// Location, Dtstart) et l'indicateur isNew exposé en tant que variable
+// `isNew` dans l'expression.
+func (p *Program) Eval(event map[string]interface{}, isNew bool) (bool, error) {
+	ctx := &evalContext{event: event, isNew: isNew}
+
+	val, err := p.expr.eval(ctx)
+	if err != nil {
+		return false, err
+	}
+	b, ok := val.(bool)
+	if !ok {
+		return false, fmt.Errorf("le filtre ne retourne pas un booléen (valeur: %v)", val)
+	}
+	return b, nil
+}
@@ -0,0 +1,142 @@
+package filter
+
+import "testing"
+
+func testEvent() map[string]interface{} {
+	return map[string]interface{}{
+		"Id":           "evt-1",
+		"Dtstamp":      "20260101T000000Z",
+		"Dtstart":      "20260101T080000Z",
+		"Dtend":        "20260101T090000Z",
+		"Description":  "Cours magistral",
+		"Location":     "Amphi A",
+		"Created":      "20251201T000000Z",
+		"LastModified": "20251215T000000Z",
+		"ResourceID":   "salle-42",
+	}
+}
+
+func eval(t *testing.T, source string, isNew bool) bool {
+	t.Helper()
+	ev := NewEvaluator()
+	program, err := ev.Compile(source)
+	if err != nil {
+		t.Fatalf("Compile(%q) a échoué: %v", source, err)
+	}
+	matched, err := program.Eval(testEvent(), isNew)
+	if err != nil {
+		t.Fatalf("Eval(%q) a échoué: %v", source, err)
+	}
+	return matched
+}
+
+func TestCompareContains(t *testing.T) {
+	if !eval(t, `event.Location contains "Amphi" && !isNew`, false) {
+		t.Error("attendu: true")
+	}
+	if eval(t, `event.Location contains "Amphi" && !isNew`, true) {
+		t.Error("attendu: false (isNew)")
+	}
+	if eval(t, `event.Location contains "Salle TP"`, false) {
+		t.Error("attendu: false (sous-chaîne absente)")
+	}
+}
+
+func TestCompareBeforeWithDurationArithmetic(t *testing.T) {
+	if !eval(t, `event.Dtstart before now() + 24h`, false) {
+		t.Error("attendu: true (Dtstart est dans le passé par rapport à maintenant + 24h)")
+	}
+	if eval(t, `event.Dtstart after now() + 24h`, false) {
+		t.Error("attendu: false")
+	}
+}
+
+func TestCompareEqAndMatches(t *testing.T) {
+	if !eval(t, `event.ResourceID == "salle-42"`, false) {
+		t.Error("attendu: true")
+	}
+	if !eval(t, `event.ResourceID != "autre-salle"`, false) {
+		t.Error("attendu: true")
+	}
+	if !eval(t, `event.Location matches "^Amphi"`, false) {
+		t.Error("attendu: true")
+	}
+}
+
+func TestLogicalOperatorsAndPrecedence(t *testing.T) {
+	// && est plus prioritaire que ||, donc ceci s'évalue comme
+	// (event.Location contains "Amphi") || (true && false) == true.
+	if !eval(t, `event.Location contains "Amphi" || true && false`, false) {
+		t.Error("attendu: true (précédence && avant ||)")
+	}
+	if eval(t, `!(event.Location contains "Amphi")`, false) {
+		t.Error("attendu: false")
+	}
+}
+
+func TestCompileCaching(t *testing.T) {
+	ev := NewEvaluator()
+	p1, err := ev.Compile(`isNew`)
+	if err != nil {
+		t.Fatalf("Compile a échoué: %v", err)
+	}
+	p2, err := ev.Compile(`isNew`)
+	if err != nil {
+		t.Fatalf("Compile a échoué: %v", err)
+	}
+	if p1 != p2 {
+		t.Error("attendu: même *Program pour une source identique (cache)")
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	cases := []string{
+		`event.Location contains "Amphi`,       // chaîne non terminée
+		`event.Location contains`,               // opérande droit manquant
+		`event.Location ? "Amphi"`,               // caractère inattendu
+		`event.Location contains "Amphi" 42`,    // nombre nu : caractère inattendu à la tokenisation
+		`event.Dtstart + "Amphi"`,                // durée attendue après +
+		`now`,                                    // now doit être appelé avec ()
+		`event foo`,                               // event doit être suivi de .champ
+		`inconnuVar`,                              // identifiant inconnu
+	}
+	for _, src := range cases {
+		ev := NewEvaluator()
+		if _, err := ev.Compile(src); err == nil {
+			t.Errorf("Compile(%q): attendu une erreur, aucune reçue", src)
+		}
+	}
+}
+
+func TestEvalNonBooleanResult(t *testing.T) {
+	ev := NewEvaluator()
+	program, err := ev.Compile(`event.Location`)
+	if err != nil {
+		t.Fatalf("Compile a échoué: %v", err)
+	}
+	if _, err := program.Eval(testEvent(), false); err == nil {
+		t.Error("attendu une erreur: le filtre ne retourne pas un booléen")
+	}
+}
+
+func TestEvalUnknownField(t *testing.T) {
+	ev := NewEvaluator()
+	program, err := ev.Compile(`event.Organizer == "x"`)
+	if err != nil {
+		t.Fatalf("Compile a échoué: %v", err)
+	}
+	if _, err := program.Eval(testEvent(), false); err == nil {
+		t.Error("attendu une erreur: champ d'événement inconnu")
+	}
+}
+
+func TestEvalTypeMismatchOnComparison(t *testing.T) {
+	ev := NewEvaluator()
+	program, err := ev.Compile(`event.Dtstart before "Amphi"`)
+	if err != nil {
+		t.Fatalf("Compile a échoué: %v", err)
+	}
+	if _, err := program.Eval(testEvent(), false); err == nil {
+		t.Error("attendu une erreur: opérande droit n'est pas une date valide")
+	}
+}
@@ -0,0 +1,324 @@
+package filter
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Grammaire (priorité croissante) :
+//
+//	expr    := or
+//	or      := and ( "||" and )*
+//	and     := unary ( "&&" unary )*
+//	unary   := "!" unary | compare
+//	compare := additive ( ("==" | "!=" | "contains" | "before" | "after" | "matches") additive )?
+//	additive:= primary ( ("+" | "-") duration )*
+//	primary := "(" expr ")" | "true" | "false" | "now" "(" ")" | string | "isNew" | "event" "." ident
+
+type tokenKind int
+
+const (
+	tokEOF tokenKind = iota
+	tokIdent
+	tokString
+	tokDuration
+	tokLParen
+	tokRParen
+	tokDot
+	tokAnd
+	tokOr
+	tokNot
+	tokEq
+	tokNeq
+	tokPlus
+	tokMinus
+)
+
+type token struct {
+	kind tokenKind
+	text string
+}
+
+var durationRe = regexp.MustCompile(`^\d+(ns|us|µs|ms|s|m|h)`)
+
+func tokenize(src string) ([]token, error) {
+	var tokens []token
+	i := 0
+	for i < len(src) {
+		c := src[i]
+		switch {
+		case c == ' ' || c == '\t' || c == '\n' || c == '\r':
+			i++
+		case c == '(':
+			tokens = append(tokens, token{tokLParen, "("})
+			i++
+		case c == ')':
+			tokens = append(tokens, token{tokRParen, ")"})
+			i++
+		case c == '.':
+			tokens = append(tokens, token{tokDot, "."})
+			i++
+		case c == '+':
+			tokens = append(tokens, token{tokPlus, "+"})
+			i++
+		case c == '-':
+			tokens = append(tokens, token{tokMinus, "-"})
+			i++
+		case c == '!' && i+1 < len(src) && src[i+1] == '=':
+			tokens = append(tokens, token{tokNeq, "!="})
+			i += 2
+		case c == '!':
+			tokens = append(tokens, token{tokNot, "!"})
+			i++
+		case c == '=' && i+1 < len(src) && src[i+1] == '=':
+			tokens = append(tokens, token{tokEq, "=="})
+			i += 2
+		case c == '&' && i+1 < len(src) && src[i+1] == '&':
+			tokens = append(tokens, token{tokAnd, "&&"})
+			i += 2
+		case c == '|' && i+1 < len(src) && src[i+1] == '|':
+			tokens = append(tokens, token{tokOr, "||"})
+			i += 2
+		case c == '"':
+			j := i + 1
+			for j < len(src) && src[j] != '"' {
+				j++
+			}
+			if j >= len(src) {
+				return nil, fmt.Errorf("chaîne non terminée")
+			}
+			tokens = append(tokens, token{tokString, src[i+1 : j]})
+			i = j + 1
+		default:
+			if loc := durationRe.FindString(src[i:]); loc != "" {
+				tokens = append(tokens, token{tokDuration, loc})
+				i += len(loc)
+				continue
+			}
+			if isIdentStart(c) {
+				j := i
+				for j < len(src) && isIdentPart(src[j]) {
+					j++
+				}
+				tokens = append(tokens, token{tokIdent, src[i:j]})
+				i = j
+				continue
+			}
+			return nil, fmt.Errorf("caractère inattendu %q à la position %d", c, i)
+		}
+	}
+	tokens = append(tokens, token{tokEOF, ""})
+	return tokens, nil
+}
+
+func isIdentStart(c byte) bool {
+	return c == '_' || (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z')
+}
+
+func isIdentPart(c byte) bool {
+	return isIdentStart(c) || (c >= '0' && c <= '9')
+}
+
+type parser struct {
+	tokens []token
+	pos    int
+}
+
+func parse(src string) (expr, error) {
+	tokens, err := tokenize(src)
+	if err != nil {
+		return nil, err
+	}
+	p := &parser{tokens: tokens}
+	ex, err := p.parseOr()
+	if err != nil {
+		return nil, err
+	}
+	if p.peek().kind != tokEOF {
+		return nil, fmt.Errorf("jeton inattendu après l'expression: %q", p.peek().text)
+	}
+	return ex, nil
+}
+
+func (p *parser) peek() token { return p.tokens[p.pos] }
+
+func (p *parser) next() token {
+	t := p.tokens[p.pos]
+	if p.pos < len(p.tokens)-1 {
+		p.pos++
+	}
+	return t
+}
+
+func (p *parser) parseOr() (expr, error) {
+	left, err := p.parseAnd()
+	if err != nil {
+		return nil, err
+	}
+	for p.peek().kind == tokOr {
+		p.next()
+		right, err := p.parseAnd()
+		if err != nil {
+			return nil, err
+		}
+		left = orExpr{left, right}
+	}
+	return left, nil
+}
+
+func (p *parser) parseAnd() (expr, error) {
+	left, err := p.parseUnary()
+	if err != nil {
+		return nil, err
+	}
+	for p.peek().kind == tokAnd {
+		p.next()
+		right, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		left = andExpr{left, right}
+	}
+	return left, nil
+}
+
+func (p *parser) parseUnary() (expr, error) {
+	if p.peek().kind == tokNot {
+		p.next()
+		operand, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		return notExpr{operand}, nil
+	}
+	return p.parseCompare()
+}
+
+var compareKeywords = map[string]compareOp{
+	"contains": opContains,
+	"before":   opBefore,
+	"after":    opAfter,
+	"matches":  opMatches,
+}
+
+func (p *parser) parseCompare() (expr, error) {
+	left, err := p.parseAdditive()
+	if err != nil {
+		return nil, err
+	}
+
+	tok := p.peek()
+	var op compareOp
+	switch tok.kind {
+	case tokEq:
+		op = opEq
+	case tokNeq:
+		op = opNeq
+	case tokIdent:
+		if kw, ok := compareKeywords[tok.text]; ok {
+			op = kw
+		} else {
+			return left, nil
+		}
+	default:
+		return left, nil
+	}
+	p.next()
+
+	right, err := p.parseAdditive()
+	if err != nil {
+		return nil, err
+	}
+	return compareExpr{left: left, right: right, op: op}, nil
+}
+
+func (p *parser) parseAdditive() (expr, error) {
+	left, err := p.parsePrimary()
+	if err != nil {
+		return nil, err
+	}
+	for p.peek().kind == tokPlus || p.peek().kind == tokMinus {
+		neg := p.peek().kind == tokMinus
+		p.next()
+
+		durTok := p.peek()
+		if durTok.kind != tokDuration {
+			return nil, fmt.Errorf("une durée (ex: 24h) est attendue après +/-, reçu %q", durTok.text)
+		}
+		p.next()
+
+		d, err := time.ParseDuration(durTok.text)
+		if err != nil {
+			return nil, fmt.Errorf("durée invalide %q: %v", durTok.text, err)
+		}
+		left = addDuration{left: left, neg: neg, delta: d}
+	}
+	return left, nil
+}
+
+func (p *parser) parsePrimary() (expr, error) {
+	tok := p.peek()
+	switch {
+	case tok.kind == tokLParen:
+		p.next()
+		ex, err := p.parseOr()
+		if err != nil {
+			return nil, err
+		}
+		if p.peek().kind != tokRParen {
+			return nil, fmt.Errorf("parenthèse fermante attendue")
+		}
+		p.next()
+		return ex, nil
+	case tok.kind == tokString:
+		p.next()
+		return stringLit(tok.text), nil
+	case tok.kind == tokIdent:
+		return p.parseIdentPrimary()
+	default:
+		return nil, fmt.Errorf("expression inattendue: %q", tok.text)
+	}
+}
+
+func (p *parser) parseIdentPrimary() (expr, error) {
+	tok := p.next()
+	switch tok.text {
+	case "true":
+		return boolLit(true), nil
+	case "false":
+		return boolLit(false), nil
+	case "isNew":
+		return isNewVar{}, nil
+	case "now":
+		if p.peek().kind != tokLParen {
+			return nil, fmt.Errorf("now doit être appelé: now()")
+		}
+		p.next()
+		if p.peek().kind != tokRParen {
+			return nil, fmt.Errorf("now() ne prend pas d'argument")
+		}
+		p.next()
+		return nowCall{}, nil
+	case "event":
+		if p.peek().kind != tokDot {
+			return nil, fmt.Errorf("event doit être suivi de .<champ>")
+		}
+		p.next()
+		field := p.next()
+		if field.kind != tokIdent {
+			return nil, fmt.Errorf("nom de champ attendu après event.")
+		}
+		return fieldRef{name: field.text}, nil
+	default:
+		// Un identifiant ne commence jamais par un chiffre, et un nombre nu
+		// est déjà rejeté à la tokenisation (caractère inattendu) : ce test
+		// ne réussit donc jamais ici.
+		if _, err := strconv.Atoi(tok.text); err == nil {
+			return nil, fmt.Errorf("les littéraux numériques nus ne sont pas supportés: %q", tok.text)
+		}
+		return nil, fmt.Errorf("identifiant inconnu: %q", strings.TrimSpace(tok.text))
+	}
+}
@@ -0,0 +1,71 @@
+// Package health suit l'état de santé du service (connectivité NATS, dernier
+// fetchAlerts réussi) et expose les handlers HTTP /healthz et /readyz.
+package health
+
+import (
+	"encoding/json"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// Status centralise l'état consulté par /healthz et /readyz. Les mises à
+// jour viennent de cmd/main.go (connexion NATS, résultat de fetchAlerts).
+type Status struct {
+	mu            sync.RWMutex
+	natsConnected bool
+	lastFetchOK   bool
+	lastFetchAt   time.Time
+}
+
+// NewStatus crée un Status vide (ni connecté, ni fetch réussi).
+func NewStatus() *Status {
+	return &Status{}
+}
+
+// SetNATSConnected met à jour l'état de connectivité NATS.
+func (s *Status) SetNATSConnected(connected bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.natsConnected = connected
+}
+
+// SetFetchResult enregistre le résultat du dernier appel à fetchAlerts.
+func (s *Status) SetFetchResult(ok bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.lastFetchOK = ok
+	s.lastFetchAt = time.Now()
+}
+
+// Ready indique si le service est prêt à traiter des événements : connecté à
+// NATS et avec un dernier fetchAlerts réussi.
+func (s *Status) Ready() bool {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.natsConnected && s.lastFetchOK
+}
+
+// HealthzHandler répond 200 tant que le processus tourne (liveness).
+func (s *Status) HealthzHandler(w http.ResponseWriter, r *http.Request) {
+	w.WriteHeader(http.StatusOK)
+	w.Write([]byte("ok"))
+}
+
+// ReadyzHandler répond 200 si le service est prêt, 503 sinon (readiness).
+func (s *Status) ReadyzHandler(w http.ResponseWriter, r *http.Request) {
+	s.mu.RLock()
+	ready := s.natsConnected && s.lastFetchOK
+	body := map[string]interface{}{
+		"nats_connected": s.natsConnected,
+		"last_fetch_ok":  s.lastFetchOK,
+		"last_fetch_at":  s.lastFetchAt,
+	}
+	s.mu.RUnlock()
+
+	w.Header().Set("Content-Type", "application/json")
+	if !ready {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}
+	json.NewEncoder(w).Encode(body)
+}
@@ -0,0 +1,65 @@
+// Package metrics déclare les métriques Prometheus exposées par le service
+// sur /metrics, instrumentées directement depuis cmd/main.go.
+package metrics
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+var (
+	EventsReceived = promauto.NewCounter(prometheus.CounterOpts{
+		Name: "alerter_events_received_total",
+		Help: "Nombre total d'événements reçus depuis NATS.",
+	})
+
+	EventsMatched = promauto.NewCounter(prometheus.CounterOpts{
+		Name: "alerter_events_matched_total",
+		Help: "Nombre total de correspondances événement/alerte (All, ResourceId ou filtre).",
+	})
+
+	NotificationsSent = promauto.NewCounter(prometheus.CounterOpts{
+		Name: "alerter_notifications_sent_total",
+		Help: "Nombre total de notifications envoyées avec succès, tous canaux confondus.",
+	})
+
+	NotificationFailures = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "alerter_notification_failures_total",
+		Help: "Nombre total d'échecs d'envoi, par code de statut HTTP (\"0\" si non applicable, ex: erreur SMTP).",
+	}, []string{"status_code"})
+
+	TemplateErrors = promauto.NewCounter(prometheus.CounterOpts{
+		Name: "alerter_template_errors_total",
+		Help: "Nombre total d'erreurs de rendu de template.",
+	})
+
+	NatsReconnects = promauto.NewCounter(prometheus.CounterOpts{
+		Name: "alerter_nats_reconnects_total",
+		Help: "Nombre total de reconnexions à NATS.",
+	})
+
+	NotifyDuration = promauto.NewHistogram(prometheus.HistogramOpts{
+		Name: "alerter_notify_duration_seconds",
+		Help: "Latence d'envoi d'une notification (API mail, SMTP, webhook).",
+	})
+
+	ProcessingDuration = promauto.NewHistogram(prometheus.HistogramOpts{
+		Name: "alerter_processing_duration_seconds",
+		Help: "Durée de traitement de bout en bout d'un événement NATS.",
+	})
+
+	LastAlertCount = promauto.NewGauge(prometheus.GaugeOpts{
+		Name: "alerter_last_alert_count",
+		Help: "Nombre d'alertes renvoyées par le dernier appel réussi à fetchAlerts.",
+	})
+
+	NatsConnected = promauto.NewGauge(prometheus.GaugeOpts{
+		Name: "alerter_nats_connected",
+		Help: "1 si la connexion NATS est active, 0 sinon.",
+	})
+
+	RateLimitDrops = promauto.NewCounter(prometheus.CounterOpts{
+		Name: "alerter_rate_limit_drops_total",
+		Help: "Nombre total de notifications immédiates abandonnées car le destinataire a dépassé son quota horaire.",
+	})
+)
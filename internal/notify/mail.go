@@ -0,0 +1,63 @@
+package notify
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"time"
+)
+
+// MailNotifier envoie les notifications via l'API mail historique du
+// service (MAIL_API_URL), authentifiée par le token MAIL_TOKEN.
+type MailNotifier struct {
+	APIURL string
+}
+
+// NewMailNotifier construit un MailNotifier ciblant l'API donnée.
+func NewMailNotifier(apiURL string) *MailNotifier {
+	return &MailNotifier{APIURL: apiURL}
+}
+
+func (n *MailNotifier) Send(target, subject, content string) error {
+	payload := map[string]interface{}{
+		"recipient": target,
+		"subject":   subject,
+		"content":   content,
+	}
+
+	jsonPayload, err := json.Marshal(payload)
+	if err != nil {
+		return fmt.Errorf("erreur de sérialisation JSON: %v", err)
+	}
+
+	req, err := http.NewRequest("POST", n.APIURL, bytes.NewBuffer(jsonPayload))
+	if err != nil {
+		return fmt.Errorf("erreur de création de la requête HTTP: %v", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	token := os.Getenv("MAIL_TOKEN")
+	if token == "" {
+		return fmt.Errorf("MAIL_TOKEN non défini")
+	}
+	req.Header.Set("Authorization", token)
+
+	client := &http.Client{Timeout: 10 * time.Second}
+	resp, err := client.Do(req)
+	if err != nil {
+		return fmt.Errorf("erreur lors de l'exécution de la requête: %v", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusNoContent {
+		body, _ := ioutil.ReadAll(resp.Body)
+		return &StatusError{
+			Code: resp.StatusCode,
+			Err:  fmt.Errorf("échec de l'envoi du mail, status: %d, body: %s", resp.StatusCode, string(body)),
+		}
+	}
+	return nil
+}
@@ -0,0 +1,24 @@
+// Package notify regroupe les différents canaux d'envoi de notifications
+// (API mail historique, SMTP direct, webhooks génériques, Mattermost,
+// Discord) derrière une interface commune, pour que processMessage puisse
+// router chaque alerte vers son canal sans connaître les détails de
+// transport.
+package notify
+
+// Notifier envoie un message déjà rendu (sujet + contenu) vers une cible.
+// Le sens de target dépend de l'implémentation : adresse email pour Mail et
+// SMTP, URL de webhook pour Webhook/Mattermost/Discord.
+type Notifier interface {
+	Send(target, subject, content string) error
+}
+
+// StatusError habille une erreur d'envoi HTTP avec le code de statut
+// renvoyé, pour que l'appelant puisse l'utiliser comme label de métrique
+// sans reparser le message d'erreur.
+type StatusError struct {
+	Code int
+	Err  error
+}
+
+func (e *StatusError) Error() string { return e.Err.Error() }
+func (e *StatusError) Unwrap() error { return e.Err }
@@ -0,0 +1,70 @@
+package notify
+
+import (
+	"crypto/tls"
+	"fmt"
+	"net"
+	"net/smtp"
+)
+
+// SMTPNotifier envoie les notifications directement via un serveur SMTP,
+// avec négociation STARTTLS si le serveur l'annonce.
+type SMTPNotifier struct {
+	Host string
+	Port string
+	User string
+	Pass string
+	From string
+}
+
+// NewSMTPNotifier construit un SMTPNotifier à partir des paramètres de
+// connexion. From est l'adresse d'expéditeur utilisée dans l'enveloppe.
+func NewSMTPNotifier(host, port, user, pass, from string) *SMTPNotifier {
+	return &SMTPNotifier{Host: host, Port: port, User: user, Pass: pass, From: from}
+}
+
+func (n *SMTPNotifier) Send(target, subject, content string) error {
+	addr := net.JoinHostPort(n.Host, n.Port)
+
+	client, err := smtp.Dial(addr)
+	if err != nil {
+		return fmt.Errorf("erreur de connexion SMTP: %v", err)
+	}
+	defer client.Close()
+
+	if ok, _ := client.Extension("STARTTLS"); ok {
+		tlsConfig := &tls.Config{ServerName: n.Host}
+		if err := client.StartTLS(tlsConfig); err != nil {
+			return fmt.Errorf("erreur STARTTLS: %v", err)
+		}
+	}
+
+	if n.User != "" {
+		auth := smtp.PlainAuth("", n.User, n.Pass, n.Host)
+		if err := client.Auth(auth); err != nil {
+			return fmt.Errorf("erreur d'authentification SMTP: %v", err)
+		}
+	}
+
+	if err := client.Mail(n.From); err != nil {
+		return fmt.Errorf("erreur MAIL FROM: %v", err)
+	}
+	if err := client.Rcpt(target); err != nil {
+		return fmt.Errorf("erreur RCPT TO: %v", err)
+	}
+
+	wc, err := client.Data()
+	if err != nil {
+		return fmt.Errorf("erreur DATA: %v", err)
+	}
+	message := fmt.Sprintf("From: %s\r\nTo: %s\r\nSubject: %s\r\n\r\n%s",
+		n.From, target, subject, content)
+	if _, err := wc.Write([]byte(message)); err != nil {
+		return fmt.Errorf("erreur d'écriture du message: %v", err)
+	}
+	if err := wc.Close(); err != nil {
+		return fmt.Errorf("erreur de clôture du message: %v", err)
+	}
+
+	return client.Quit()
+}
@@ -0,0 +1,81 @@
+package notify
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"time"
+)
+
+// WebhookNotifier POSTe un JSON générique {subject, content} vers l'URL
+// donnée en target, pour les intégrations qui ne parlent ni mail ni chat.
+type WebhookNotifier struct {
+	client *http.Client
+}
+
+// NewWebhookNotifier construit un WebhookNotifier.
+func NewWebhookNotifier() *WebhookNotifier {
+	return &WebhookNotifier{client: &http.Client{Timeout: 10 * time.Second}}
+}
+
+func (n *WebhookNotifier) Send(target, subject, content string) error {
+	return postJSON(n.client, target, map[string]interface{}{
+		"subject": subject,
+		"content": content,
+	})
+}
+
+// ChatNotifier POSTe un message au format attendu par les webhooks entrants
+// Mattermost/Slack ({"text": ...}) ou Discord ({"content": ...}).
+type ChatNotifier struct {
+	client  *http.Client
+	bodyKey string
+}
+
+// NewMattermostNotifier construit un ChatNotifier pour un webhook entrant
+// Mattermost (compatible Slack).
+func NewMattermostNotifier() *ChatNotifier {
+	return &ChatNotifier{client: &http.Client{Timeout: 10 * time.Second}, bodyKey: "text"}
+}
+
+// NewDiscordNotifier construit un ChatNotifier pour un webhook entrant Discord.
+func NewDiscordNotifier() *ChatNotifier {
+	return &ChatNotifier{client: &http.Client{Timeout: 10 * time.Second}, bodyKey: "content"}
+}
+
+func (n *ChatNotifier) Send(target, subject, content string) error {
+	message := fmt.Sprintf("**%s**\n%s", subject, content)
+	return postJSON(n.client, target, map[string]interface{}{
+		n.bodyKey: message,
+	})
+}
+
+func postJSON(client *http.Client, url string, payload map[string]interface{}) error {
+	jsonPayload, err := json.Marshal(payload)
+	if err != nil {
+		return fmt.Errorf("erreur de sérialisation JSON: %v", err)
+	}
+
+	req, err := http.NewRequest("POST", url, bytes.NewBuffer(jsonPayload))
+	if err != nil {
+		return fmt.Errorf("erreur de création de la requête HTTP: %v", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return fmt.Errorf("erreur lors de l'exécution de la requête: %v", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 300 {
+		body, _ := ioutil.ReadAll(resp.Body)
+		return &StatusError{
+			Code: resp.StatusCode,
+			Err:  fmt.Errorf("échec du webhook, status: %d, body: %s", resp.StatusCode, string(body)),
+		}
+	}
+	return nil
+}